@@ -0,0 +1,30 @@
+package exportutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+// Digest computes an HMAC-SHA256 digest of payload keyed on signingKey,
+// hex-encoded. Bundle types call this with their own JSON encoding (with
+// Signature cleared first) to implement Sign/Verify.
+func Digest(payload []byte, signingKey string) string {
+	mac := hmac.New(sha256.New, []byte(signingKey))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyDigest reports an error if signature doesn't match the HMAC-SHA256
+// digest of payload keyed on signingKey. Callers must reject an import
+// whose signature doesn't verify - it means the bundle was tampered with
+// or signed with a different key, and promoting it between environments
+// would be unsafe.
+func VerifyDigest(payload []byte, signingKey, signature string) error {
+	if !hmac.Equal([]byte(Digest(payload, signingKey)), []byte(signature)) {
+		return errors.New("bundle signature does not match, refusing to import")
+	}
+	return nil
+}
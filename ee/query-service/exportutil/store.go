@@ -0,0 +1,102 @@
+// Package exportutil holds the object-storage and signing plumbing
+// shared by ingestionRules/export and pipelines/export: both packages
+// ship a signed bundle (of a different shape) to the same kind of
+// S3-compatible object store, so that plumbing lives here once instead
+// of being duplicated per package.
+package exportutil
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+// ObjectStore is the minimal read/write surface PushBundle/PullBundle
+// need. S3ObjectStore is the only production implementation, but the
+// interface lets callers swap in a fake for a dry-run or a test.
+type ObjectStore interface {
+	Put(ctx context.Context, key string, body io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// S3ObjectStore stores bundles in an S3-compatible bucket. Pointing
+// Endpoint at MinIO or GCS's S3 interop endpoint (with
+// ForcePathStyle=true) works the same as talking to AWS S3 directly.
+type S3ObjectStore struct {
+	client *s3.S3
+	bucket string
+}
+
+// S3Config holds the connection details for an S3-compatible endpoint.
+// Endpoint may be left empty to use AWS S3 directly.
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyId     string
+	SecretAccessKey string
+	ForcePathStyle  bool
+}
+
+// NewS3ObjectStore builds an ObjectStore backed by an S3-compatible
+// bucket from the given config.
+func NewS3ObjectStore(cfg S3Config) (*S3ObjectStore, error) {
+	awsCfg := aws.NewConfig().
+		WithRegion(cfg.Region).
+		WithS3ForcePathStyle(cfg.ForcePathStyle)
+
+	if cfg.Endpoint != "" {
+		awsCfg = awsCfg.WithEndpoint(cfg.Endpoint)
+	}
+
+	if cfg.AccessKeyId != "" {
+		awsCfg = awsCfg.WithCredentials(
+			credentials.NewStaticCredentials(cfg.AccessKeyId, cfg.SecretAccessKey, ""))
+	}
+
+	sess, err := session.NewSession(awsCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create object storage session")
+	}
+
+	return &S3ObjectStore{
+		client: s3.New(sess),
+		bucket: cfg.Bucket,
+	}, nil
+}
+
+func (s *S3ObjectStore) Put(ctx context.Context, key string, body io.Reader) error {
+	buf, err := io.ReadAll(body)
+	if err != nil {
+		return errors.Wrap(err, "failed to read bundle before upload")
+	}
+
+	_, err = s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(buf),
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to upload bundle to object storage")
+	}
+
+	return nil
+}
+
+func (s *S3ObjectStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to download bundle from object storage")
+	}
+
+	return out.Body, nil
+}
@@ -0,0 +1,195 @@
+package ingestionRules
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/ingestionRules/export"
+	"go.uber.org/zap"
+)
+
+// RuleDiff is the structured result of comparing a bundle against the
+// current rule set: rules only present in the bundle, rules only
+// present in the current set, and rules present in both whose config
+// differs.
+type RuleDiff struct {
+	Added    []export.Rule `json:"added"`
+	Removed  []export.Rule `json:"removed"`
+	Modified []export.Rule `json:"modified"`
+}
+
+// ExportBundle serializes every ingestion rule into a single bundle
+// written to w and signed with signingKey. The bundle is meant to be
+// pushed to object storage and later replayed with ImportBundle against
+// another environment using the same signingKey.
+func (r *Repo) ExportBundle(ctx context.Context, w io.Writer, signingKey string) error {
+	rules := []IngestionRule{}
+	if err := r.db.SelectContext(ctx, &rules, `SELECT id, source, priority, rule_type, rule_subtype, name, config_json, deployment_status, deployment_sequence FROM ingestion_rules`); err != nil {
+		zap.S().Errorf("failed to load ingestion rules for export", err)
+		return errors.Wrap(err, "failed to load ingestion rules for export")
+	}
+
+	bundle := &export.Bundle{}
+	for _, rule := range rules {
+		bundle.Rules = append(bundle.Rules, export.Rule{
+			Id:          rule.Id,
+			Name:        rule.Name,
+			Source:      rule.Source,
+			RuleType:    string(rule.RuleType),
+			RuleSubType: rule.RuleSubType,
+			Priority:    rule.Priority,
+			RawConfig:   rule.RawConfig,
+		})
+	}
+
+	if err := bundle.Sign(signingKey); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(bundle)
+}
+
+// ImportBundle reconciles the rule set in r with the one described by
+// the bundle read from r2, according to mode. ImportDryRun applies no
+// changes and returns the diff a merge import would produce;
+// ImportReplace and ImportMerge apply the bundle and return the
+// resulting diff against the pre-import state.
+//
+// Import is idempotent: bundle rules carry their source id, so
+// importing the same bundle twice leaves the rule set unchanged the
+// second time. All deletes, inserts and updates are applied in a single
+// transaction so a failure partway through never leaves a half-applied
+// bundle.
+func (r *Repo) ImportBundle(ctx context.Context, r2 io.Reader, mode export.ImportMode, signingKey string) (*RuleDiff, error) {
+	bundle := &export.Bundle{}
+	if err := json.NewDecoder(r2).Decode(bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to decode bundle")
+	}
+
+	if err := bundle.Verify(signingKey); err != nil {
+		return nil, err
+	}
+
+	current := []IngestionRule{}
+	if err := r.db.SelectContext(ctx, &current, `SELECT id, source, priority, rule_type, rule_subtype, name, config_json FROM ingestion_rules`); err != nil {
+		return nil, errors.Wrap(err, "failed to load current ingestion rules for import")
+	}
+
+	currentById := make(map[string]IngestionRule, len(current))
+	for _, rule := range current {
+		currentById[rule.Id] = rule
+	}
+
+	diff := &RuleDiff{}
+	for _, incoming := range bundle.Rules {
+		existing, ok := currentById[incoming.Id]
+		if !ok {
+			diff.Added = append(diff.Added, incoming)
+			continue
+		}
+		if existing.RawConfig != incoming.RawConfig {
+			diff.Modified = append(diff.Modified, incoming)
+		}
+	}
+
+	if mode == export.ImportReplace {
+		bundledIds := make(map[string]bool, len(bundle.Rules))
+		for _, rule := range bundle.Rules {
+			bundledIds[rule.Id] = true
+		}
+		for _, rule := range current {
+			if !bundledIds[rule.Id] {
+				diff.Removed = append(diff.Removed, export.Rule{Id: rule.Id, Name: rule.Name})
+			}
+		}
+	}
+
+	if mode == export.ImportDryRun {
+		return diff, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start import transaction")
+	}
+	defer tx.Rollback()
+
+	if mode == export.ImportReplace {
+		for _, removed := range diff.Removed {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM ingestion_rules WHERE id = $1`, removed.Id); err != nil {
+				return nil, errors.Wrap(err, "failed to delete ingestion rule removed by import")
+			}
+		}
+	}
+
+	for _, incoming := range bundle.Rules {
+		if existing, ok := currentById[incoming.Id]; ok {
+			if existing.RawConfig == incoming.RawConfig {
+				// Already applied, nothing to do - this is what keeps
+				// re-importing the same bundle idempotent.
+				continue
+			}
+
+			updateQuery := `UPDATE ingestion_rules
+				SET name = $1, source = $2, rule_type = $3, rule_subtype = $4,
+				priority = $5, config_json = $6, deployment_status = $7, deployment_sequence = $8
+				WHERE id = $9`
+
+			if _, err := tx.ExecContext(ctx, updateQuery,
+				incoming.Name, incoming.Source, incoming.RuleType, incoming.RuleSubType,
+				incoming.Priority, incoming.RawConfig, PendingDeploy, -2, incoming.Id); err != nil {
+				return nil, errors.Wrap(err, "failed to update modified ingestion rule during import")
+			}
+			continue
+		}
+
+		insertQuery := `INSERT INTO ingestion_rules
+			(id, name, source, rule_type, rule_subtype, priority, config_json, deployment_status, deployment_sequence)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			incoming.Id, incoming.Name, incoming.Source, incoming.RuleType, incoming.RuleSubType,
+			incoming.Priority, incoming.RawConfig, PendingDeploy, -2); err != nil {
+			return nil, errors.Wrap(err, "failed to import ingestion rule")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "failed to commit import transaction")
+	}
+
+	return diff, nil
+}
+
+// PushBundle exports the current rule set and uploads it to an object
+// store under key, for GitOps-style promotion between environments.
+func (r *Repo) PushBundle(ctx context.Context, store export.ObjectStore, key string, signingKey string) error {
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- r.ExportBundle(ctx, pw, signingKey)
+		pw.Close()
+	}()
+
+	if err := store.Put(ctx, key, pr); err != nil {
+		return err
+	}
+
+	return <-errCh
+}
+
+// PullBundle downloads a bundle from key in an object store and applies
+// it to r according to mode. This gives operators a disaster-recovery
+// path that doesn't depend on the SQLite file surviving.
+func (r *Repo) PullBundle(ctx context.Context, store export.ObjectStore, key string, mode export.ImportMode, signingKey string) (*RuleDiff, error) {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return r.ImportBundle(ctx, body, mode, signingKey)
+}
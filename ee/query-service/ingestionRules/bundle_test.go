@@ -0,0 +1,100 @@
+package ingestionRules
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"go.signoz.io/signoz/ee/query-service/ingestionRules/export"
+)
+
+func newBundleTestRepo(t *testing.T) Repo {
+	t.Helper()
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`CREATE TABLE ingestion_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT,
+		source TEXT,
+		rule_type TEXT,
+		rule_subtype TEXT,
+		priority INTEGER,
+		config_json TEXT,
+		deployment_status TEXT,
+		deployment_sequence INTEGER,
+		error_message TEXT
+	)`)
+	if err != nil {
+		t.Fatalf("failed to create test schema: %v", err)
+	}
+
+	return NewRepo(db)
+}
+
+// TestImportBundle_ReimportIsNoop asserts the idempotency guarantee
+// ImportBundle's doc comment promises: importing the same bundle twice
+// leaves the rule set, and the reported diff, unchanged the second time.
+func TestImportBundle_ReimportIsNoop(t *testing.T) {
+	repo := newBundleTestRepo(t)
+	ctx := context.Background()
+	const signingKey = "test-signing-key"
+
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO ingestion_rules
+		(id, name, source, rule_type, rule_subtype, priority, config_json, deployment_status, deployment_sequence)
+		VALUES ('r1', 'r1', '', 'drop', '', 1, '{"a":1}', 'DEPLOYED', 1)`)
+	if err != nil {
+		t.Fatalf("failed to seed rule: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := repo.ExportBundle(ctx, &buf, signingKey); err != nil {
+		t.Fatalf("ExportBundle returned error: %v", err)
+	}
+
+	firstDiff, err := repo.ImportBundle(ctx, bytes.NewReader(buf.Bytes()), export.ImportMerge, signingKey)
+	if err != nil {
+		t.Fatalf("first ImportBundle returned error: %v", err)
+	}
+	if len(firstDiff.Added) != 0 || len(firstDiff.Modified) != 0 {
+		t.Fatalf("first import of an unmodified bundle should be a no-op diff, got %+v", firstDiff)
+	}
+
+	secondDiff, err := repo.ImportBundle(ctx, bytes.NewReader(buf.Bytes()), export.ImportMerge, signingKey)
+	if err != nil {
+		t.Fatalf("second ImportBundle returned error: %v", err)
+	}
+	if len(secondDiff.Added) != 0 || len(secondDiff.Modified) != 0 {
+		t.Fatalf("re-import of the same bundle must be a no-op, got %+v", secondDiff)
+	}
+
+	var count int
+	if err := repo.db.Get(&count, `SELECT COUNT(*) FROM ingestion_rules`); err != nil {
+		t.Fatalf("failed to count rules after reimport: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("expected exactly 1 rule after two imports of the same bundle, got %d", count)
+	}
+}
+
+// TestImportBundle_RejectsBadSignature ensures a bundle signed with a
+// different key (or tampered in transit) is refused rather than applied.
+func TestImportBundle_RejectsBadSignature(t *testing.T) {
+	repo := newBundleTestRepo(t)
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	if err := repo.ExportBundle(ctx, &buf, "signed-with-this-key"); err != nil {
+		t.Fatalf("ExportBundle returned error: %v", err)
+	}
+
+	if _, err := repo.ImportBundle(ctx, bytes.NewReader(buf.Bytes()), export.ImportMerge, "a-different-key"); err == nil {
+		t.Fatal("expected ImportBundle to reject a bundle signed with a different key, got nil error")
+	}
+}
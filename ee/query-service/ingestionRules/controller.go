@@ -0,0 +1,135 @@
+package ingestionRules
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// Skipped marks a rule that an operator explicitly skipped during a
+// rerun. Skipped rules are excluded from GetDropRulesByStatus(PendingDeploy)
+// so they don't block the rest of the sequence, but the row (and its
+// deploy history) is retained for audit.
+const Skipped DeployStatus = "SKIPPED"
+
+// DeploymentController drives rerun/skip recovery for ingestion rule
+// deployments on top of Repo. It borrows the skip-and-rerun-failed-tasks
+// pattern common to pipeline schedulers: a failed sequence can either be
+// retried as a new deploy or have its broken rules skipped so the rest
+// of the set can still go out.
+type DeploymentController struct {
+	repo *Repo
+}
+
+// NewDeploymentController wires a DeploymentController to an existing
+// ingestion rules Repo.
+func NewDeploymentController(repo *Repo) *DeploymentController {
+	return &DeploymentController{repo: repo}
+}
+
+// RerunFailed resets all rules of ruleType currently marked Failed back
+// to PendingDeploy, using the sentinel sequence (-2) EditRule already
+// uses for edit-triggered redeploys, then immediately bumps a fresh
+// deploy sequence via MarkDeploying so the reset rules go out again
+// rather than sitting PendingDeploy until the next unrelated deploy.
+func (c *DeploymentController) RerunFailed(ctx context.Context, ruleType IngestionRuleType) error {
+	failed, errs := c.repo.GetRulesByTypeAndStatus(ctx, ruleType, Failed)
+	if len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to fetch failed rules")
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	failedIDs := make([]string, 0, len(failed))
+	for _, rule := range failed {
+		failedIDs = append(failedIDs, rule.Id)
+	}
+
+	resetQuery := `UPDATE ingestion_rules
+	SET deployment_status = $1,
+	deployment_sequence = $2,
+	error_message = ''
+	WHERE rule_type = $3 AND deployment_status = $4`
+
+	_, err := c.repo.db.ExecContext(ctx, resetQuery, PendingDeploy, -2, ruleType, Failed)
+	if err != nil {
+		zap.S().Errorf("failed to reset failed ingestion rules for rerun", err)
+		return errors.Wrap(err, "failed to reset failed ingestion rules for rerun")
+	}
+
+	for _, id := range failedIDs {
+		c.recordHistory(ctx, id, -2, PendingDeploy, "")
+	}
+
+	var nextSeq int
+	seqQuery := `SELECT COALESCE(MAX(deployment_sequence), 0) + 1 FROM ingestion_rules WHERE rule_type = $1`
+	if err := c.repo.db.GetContext(ctx, &nextSeq, seqQuery, ruleType); err != nil {
+		zap.S().Errorf("failed to compute next deploy sequence for rerun", err)
+		return errors.Wrap(err, "failed to compute next deploy sequence for rerun")
+	}
+
+	if err := c.repo.MarkDeploying(ctx, nextSeq, ruleType); err != nil {
+		return errors.Wrap(err, "failed to bump deploy sequence for rerun")
+	}
+
+	for _, id := range failedIDs {
+		c.recordHistory(ctx, id, nextSeq, Deploying, "")
+	}
+
+	return nil
+}
+
+// SkipFailed marks the given rule ids as Skipped so they are excluded
+// from the next GetDropRulesByStatus(PendingDeploy) query while the rest
+// of a broken sequence can still be deployed. The rows (and their
+// history) are retained for audit, they are not deleted.
+func (c *DeploymentController) SkipFailed(ctx context.Context, ruleIDs ...string) error {
+	if len(ruleIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`UPDATE ingestion_rules SET deployment_status = ? WHERE id IN (?)`, Skipped, ruleIDs)
+	if err != nil {
+		return errors.Wrap(err, "failed to build skip query")
+	}
+
+	_, err = c.repo.db.ExecContext(ctx, c.repo.db.Rebind(query), args...)
+	if err != nil {
+		zap.S().Errorf("failed to mark ingestion rules as skipped", err)
+		return errors.Wrap(err, "failed to mark ingestion rules as skipped")
+	}
+
+	for _, id := range ruleIDs {
+		c.recordHistory(ctx, id, -1, Skipped, "")
+	}
+
+	return nil
+}
+
+// recordHistory appends a deploy status transition for a rule to
+// ingestion_rule_deploy_history. History is best-effort: a failure to
+// record it should not fail the rerun/skip operation itself, so errors
+// are logged and swallowed.
+func (c *DeploymentController) recordHistory(ctx context.Context, ruleID string, seq int, status DeployStatus, errorMessage string) {
+	insertQuery := `INSERT INTO ingestion_rule_deploy_history
+	(id, rule_id, deployment_sequence, deployment_status, error_message, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := c.repo.db.ExecContext(ctx, insertQuery,
+		uuid.New().String(),
+		ruleID,
+		seq,
+		string(status),
+		errorMessage,
+		time.Now())
+
+	if err != nil {
+		zap.S().Errorf("failed to record ingestion rule deploy history", err)
+	}
+}
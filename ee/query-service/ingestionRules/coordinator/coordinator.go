@@ -0,0 +1,312 @@
+package coordinator
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/ingestionRules"
+	"go.uber.org/zap"
+)
+
+// AgentConfigWriter pushes a batch of deployed rules to the collector
+// agents via opamp. It's satisfied by the existing opamp agent-config
+// writer; kept as an interface here so the coordinator can be tested
+// without a live opamp server.
+type AgentConfigWriter interface {
+	WriteIngestionRules(ctx context.Context, ruleType string, rules []ingestionRules.IngestionRule) error
+}
+
+// Coordinator wraps ingestionRules.Repo with policy-driven rollout:
+// instead of MarkDeploying flipping every rule to Deploying in one shot,
+// a rollout walks pending rules in priority order, in batches, and
+// auto-pauses on the first batch that fails so a single broken rule
+// can't take the whole set down with it. It only ever manages ingestion
+// rule rollouts - restoring a prior pipelines.Repo agent_config_versions
+// row is a separate, unrelated operation (see Rollback).
+type Coordinator struct {
+	db     *sqlx.DB
+	rules  *ingestionRules.Repo
+	writer AgentConfigWriter
+}
+
+// NewCoordinator wires a Coordinator to its own rollout-state table plus
+// the repo and agent-config writer it drives.
+func NewCoordinator(db *sqlx.DB, rules *ingestionRules.Repo, writer AgentConfigWriter) *Coordinator {
+	return &Coordinator{db: db, rules: rules, writer: writer}
+}
+
+// Start begins a new rollout for ruleType: it reads every PendingDeploy
+// rule, orders them by Priority (highest first, matching the ordering
+// EditRule/InsertRule already document), and deploys the first batch per
+// policy. The rest of the batches are deployed as ReportBatchResult
+// reports success for the previous one.
+func (c *Coordinator) Start(ctx context.Context, ruleType ingestionRules.IngestionRuleType, trigger Trigger, policy RolloutPolicy) (*Rollout, error) {
+	toDeploy, errs := c.rules.GetRulesByTypeAndStatus(ctx, ruleType, ingestionRules.PendingDeploy)
+	if len(errs) > 0 {
+		return nil, errors.Wrap(errs[0], "failed to load pending ingestion rules")
+	}
+
+	sort.SliceStable(toDeploy, func(i, j int) bool {
+		return toDeploy[i].Priority > toDeploy[j].Priority
+	})
+
+	rollout := &Rollout{
+		Id:         uuid.New().String(),
+		RuleType:   string(ruleType),
+		Trigger:    trigger,
+		PolicyKind: string(policy.Kind),
+		BatchSize:  policy.batchSize(len(toDeploy)),
+		Status:     RolloutRunning,
+	}
+
+	if err := c.persist(ctx, rollout); err != nil {
+		return nil, err
+	}
+
+	if len(toDeploy) == 0 {
+		rollout.Status = RolloutCompleted
+		return rollout, c.updateStatus(ctx, rollout)
+	}
+
+	if err := c.deployNextBatch(ctx, rollout, toDeploy); err != nil {
+		return nil, err
+	}
+
+	return rollout, nil
+}
+
+// deployNextBatch deploys up to rollout.BatchSize rules from pending,
+// bumping the deployment sequence and handing them to the agent-config
+// writer. Only the rule ids in this batch are flipped to Deploying -
+// MarkDeploying's rule_type-wide update would also re-stamp rules
+// already Deployed by an earlier batch (or stuck in a later one) with
+// this batch's sequence, which is exactly the "one broken rule takes
+// down the whole set" failure mode this coordinator exists to avoid.
+func (c *Coordinator) deployNextBatch(ctx context.Context, rollout *Rollout, pending []ingestionRules.IngestionRule) error {
+	batchSize := rollout.BatchSize
+	if batchSize <= 0 || batchSize > len(pending) {
+		batchSize = len(pending)
+	}
+	batch := pending[:batchSize]
+
+	batchIds := make([]string, 0, len(batch))
+	for _, rule := range batch {
+		batchIds = append(batchIds, rule.Id)
+	}
+
+	seq := rollout.CurrentSequence + 1
+	if err := c.setBatchStatus(ctx, batchIds, ingestionRules.Deploying, seq, ""); err != nil {
+		return errors.Wrap(err, "failed to mark rollout batch deploying")
+	}
+
+	if err := c.writer.WriteIngestionRules(ctx, rollout.RuleType, batch); err != nil {
+		zap.S().Errorf("failed to write ingestion rule batch to agent config", err)
+		rollout.CurrentSequence = seq
+		rollout.CurrentBatchIds = strings.Join(batchIds, ",")
+		return c.fail(ctx, rollout, err.Error())
+	}
+
+	rollout.CurrentSequence = seq
+	rollout.CurrentBatchIds = strings.Join(batchIds, ",")
+	return c.updateStatus(ctx, rollout)
+}
+
+// setBatchStatus sets deployment_status, deployment_sequence and
+// error_message for exactly the rule ids in batchIds - never by rule_type or
+// deployment_sequence alone, so this rollout's batch can't affect rows
+// outside it.
+func (c *Coordinator) setBatchStatus(ctx context.Context, batchIds []string, status ingestionRules.DeployStatus, seq int, errorMessage string) error {
+	if len(batchIds) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(
+		`UPDATE ingestion_rules SET deployment_status = ?, deployment_sequence = ?, error_message = ? WHERE id IN (?)`,
+		status, seq, errorMessage, batchIds)
+	if err != nil {
+		return errors.Wrap(err, "failed to build batch status query")
+	}
+
+	if _, err := c.db.ExecContext(ctx, c.db.Rebind(query), args...); err != nil {
+		return errors.Wrap(err, "failed to update batch status")
+	}
+
+	return nil
+}
+
+// ReportBatchResult is called back once a deployed batch's sequence has
+// either been accepted or rejected by the agent. On success it advances
+// to the next batch; on failure it auto-pauses the rollout and marks the
+// rest of the set Blocked so operators can Resume, Abort or Rollback.
+func (c *Coordinator) ReportBatchResult(ctx context.Context, rolloutId string, success bool, errorMessage string) error {
+	rollout, err := c.get(ctx, rolloutId)
+	if err != nil {
+		return err
+	}
+
+	if !success {
+		return c.fail(ctx, rollout, errorMessage)
+	}
+
+	batchIds := splitBatchIds(rollout.CurrentBatchIds)
+	if err := c.setBatchStatus(ctx, batchIds, ingestionRules.Deployed, rollout.CurrentSequence, ""); err != nil {
+		return errors.Wrap(err, "failed to mark rollout batch deployed")
+	}
+
+	remaining, errs := c.rules.GetRulesByTypeAndStatus(ctx, ingestionRules.IngestionRuleType(rollout.RuleType), ingestionRules.PendingDeploy)
+	if len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to load pending ingestion rules")
+	}
+
+	if len(remaining) == 0 {
+		rollout.Status = RolloutCompleted
+		return c.updateStatus(ctx, rollout)
+	}
+
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Priority > remaining[j].Priority
+	})
+
+	return c.deployNextBatch(ctx, rollout, remaining)
+}
+
+// fail marks rollout.CurrentBatchIds (and only those ids) Failed, and
+// blocks the rest of the rule type's still-pending rules so the
+// coordinator stops handing out new batches until an operator decides
+// to Resume, Abort or Rollback.
+func (c *Coordinator) fail(ctx context.Context, rollout *Rollout, errorMessage string) error {
+	batchIds := splitBatchIds(rollout.CurrentBatchIds)
+	if err := c.setBatchStatus(ctx, batchIds, ingestionRules.Failed, rollout.CurrentSequence, errorMessage); err != nil {
+		return errors.Wrap(err, "failed to mark rollout batch failed")
+	}
+
+	blockQuery := `UPDATE ingestion_rules
+	SET deployment_status = $1
+	WHERE rule_type = $2 AND deployment_status = $3`
+
+	if _, err := c.db.ExecContext(ctx, blockQuery, ingestionRules.Blocked, rollout.RuleType, ingestionRules.PendingDeploy); err != nil {
+		zap.S().Errorf("failed to block remaining rollout batches", err)
+	}
+
+	rollout.Status = RolloutPaused
+	rollout.ErrorMessage = errorMessage
+	return c.updateStatus(ctx, rollout)
+}
+
+// splitBatchIds parses the comma-joined id list stored on a Rollout.
+func splitBatchIds(joined string) []string {
+	if joined == "" {
+		return nil
+	}
+	return strings.Split(joined, ",")
+}
+
+// Resume un-blocks a paused rollout's remaining rules and deploys the
+// next batch.
+func (c *Coordinator) Resume(ctx context.Context, rolloutId string) error {
+	rollout, err := c.get(ctx, rolloutId)
+	if err != nil {
+		return err
+	}
+	if rollout.Status != RolloutPaused {
+		return errors.New("rollout is not paused")
+	}
+
+	unblockQuery := `UPDATE ingestion_rules
+	SET deployment_status = $1
+	WHERE rule_type = $2 AND deployment_status = $3`
+
+	if _, err := c.db.ExecContext(ctx, unblockQuery, ingestionRules.PendingDeploy, rollout.RuleType, ingestionRules.Blocked); err != nil {
+		return errors.Wrap(err, "failed to unblock rollout")
+	}
+
+	remaining, errs := c.rules.GetRulesByTypeAndStatus(ctx, ingestionRules.IngestionRuleType(rollout.RuleType), ingestionRules.PendingDeploy)
+	if len(errs) > 0 {
+		return errors.Wrap(errs[0], "failed to load pending ingestion rules")
+	}
+
+	sort.SliceStable(remaining, func(i, j int) bool {
+		return remaining[i].Priority > remaining[j].Priority
+	})
+
+	rollout.Status = RolloutRunning
+	rollout.ErrorMessage = ""
+	if err := c.updateStatus(ctx, rollout); err != nil {
+		return err
+	}
+
+	if len(remaining) == 0 {
+		rollout.Status = RolloutCompleted
+		return c.updateStatus(ctx, rollout)
+	}
+
+	return c.deployNextBatch(ctx, rollout, remaining)
+}
+
+// Abort leaves the already-deployed batches in place but gives up on the
+// rest of the rollout: blocked rules stay Blocked until an operator
+// edits or deletes them.
+func (c *Coordinator) Abort(ctx context.Context, rolloutId string) error {
+	rollout, err := c.get(ctx, rolloutId)
+	if err != nil {
+		return err
+	}
+
+	rollout.Status = RolloutAborted
+	return c.updateStatus(ctx, rollout)
+}
+
+// Rollback aborts the rollout. This coordinator only tracks ingestion
+// rule rollouts, so there is no prior agent_config_versions row of its
+// own to restore; if undoing this rollout should also roll a downstream
+// pipeline config back to an earlier version, the caller makes that a
+// separate, explicit call to pipelines.Repo.RollbackToVersion rather
+// than Rollback guessing at it.
+func (c *Coordinator) Rollback(ctx context.Context, rolloutId string) error {
+	return c.Abort(ctx, rolloutId)
+}
+
+func (c *Coordinator) persist(ctx context.Context, r *Rollout) error {
+	now := time.Now()
+	insertQuery := `INSERT INTO ingestion_rule_rollouts
+	(id, rule_type, trigger, policy_kind, batch_size, current_sequence, current_batch_ids, status, error_message, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)`
+
+	_, err := c.db.ExecContext(ctx, insertQuery,
+		r.Id, r.RuleType, r.Trigger, r.PolicyKind, r.BatchSize, r.CurrentSequence, r.CurrentBatchIds, r.Status, r.ErrorMessage, now)
+	if err != nil {
+		return errors.Wrap(err, "failed to persist rollout")
+	}
+	return nil
+}
+
+func (c *Coordinator) updateStatus(ctx context.Context, r *Rollout) error {
+	updateQuery := `UPDATE ingestion_rule_rollouts
+	SET current_sequence = $1, current_batch_ids = $2, status = $3, error_message = $4, updated_at = $5
+	WHERE id = $6`
+
+	_, err := c.db.ExecContext(ctx, updateQuery, r.CurrentSequence, r.CurrentBatchIds, r.Status, r.ErrorMessage, time.Now(), r.Id)
+	if err != nil {
+		return errors.Wrap(err, "failed to update rollout status")
+	}
+	return nil
+}
+
+func (c *Coordinator) get(ctx context.Context, rolloutId string) (*Rollout, error) {
+	rollouts := []Rollout{}
+	query := `SELECT id, rule_type, trigger, policy_kind, batch_size, current_sequence, current_batch_ids, status, error_message
+	FROM ingestion_rule_rollouts WHERE id = $1`
+
+	if err := c.db.SelectContext(ctx, &rollouts, query, rolloutId); err != nil {
+		return nil, errors.Wrap(err, "failed to load rollout")
+	}
+	if len(rollouts) == 0 {
+		return nil, errors.New("rollout not found")
+	}
+	return &rollouts[0], nil
+}
@@ -0,0 +1,228 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+	"go.signoz.io/signoz/ee/query-service/ingestionRules"
+)
+
+// newTestDB builds an in-memory sqlite database with just the tables the
+// coordinator touches. It intentionally doesn't go through
+// ingestionRules/sqlite.InitDB, since the coordinator tests only care
+// about ingestion_rules and ingestion_rule_rollouts.
+func newTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+
+	schema := []string{
+		`CREATE TABLE ingestion_rules (
+			id TEXT PRIMARY KEY,
+			name TEXT,
+			source TEXT,
+			rule_type TEXT,
+			rule_subtype TEXT,
+			priority INTEGER,
+			config_json TEXT,
+			deployment_status TEXT,
+			deployment_sequence INTEGER,
+			error_message TEXT,
+			updated_at TIMESTAMP
+		)`,
+		`CREATE TABLE ingestion_rule_rollouts (
+			id TEXT PRIMARY KEY,
+			rule_type TEXT NOT NULL,
+			trigger TEXT NOT NULL,
+			policy_kind TEXT NOT NULL,
+			batch_size INTEGER NOT NULL DEFAULT 0,
+			current_sequence INTEGER NOT NULL DEFAULT 0,
+			current_batch_ids TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL,
+			error_message TEXT,
+			created_at TIMESTAMP NOT NULL,
+			updated_at TIMESTAMP NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to create test schema: %v", err)
+		}
+	}
+
+	return db
+}
+
+func insertPendingRule(t *testing.T, db *sqlx.DB, id, ruleType string, priority int) {
+	t.Helper()
+	_, err := db.Exec(`INSERT INTO ingestion_rules
+		(id, name, source, rule_type, rule_subtype, priority, config_json, deployment_status, deployment_sequence)
+		VALUES ($1, $1, '', $2, '', $3, '{}', $4, -1)`,
+		id, ruleType, priority, ingestionRules.PendingDeploy)
+	if err != nil {
+		t.Fatalf("failed to insert pending rule %s: %v", id, err)
+	}
+}
+
+func ruleStatus(t *testing.T, db *sqlx.DB, id string) ingestionRules.DeployStatus {
+	t.Helper()
+	var status string
+	if err := db.Get(&status, `SELECT deployment_status FROM ingestion_rules WHERE id = $1`, id); err != nil {
+		t.Fatalf("failed to load status for rule %s: %v", id, err)
+	}
+	return ingestionRules.DeployStatus(status)
+}
+
+// fakeWriter records every batch it's handed and fails whichever calls
+// are listed in failOn (0-indexed), so tests can force a batch to be
+// rejected by the "agent".
+type fakeWriter struct {
+	failOn map[int]bool
+	calls  [][]ingestionRules.IngestionRule
+}
+
+func (f *fakeWriter) WriteIngestionRules(ctx context.Context, ruleType string, rules []ingestionRules.IngestionRule) error {
+	idx := len(f.calls)
+	f.calls = append(f.calls, rules)
+	if f.failOn[idx] {
+		return errors.New("agent rejected batch")
+	}
+	return nil
+}
+
+func newTestCoordinator(db *sqlx.DB, writer AgentConfigWriter) *Coordinator {
+	rules := ingestionRules.NewRepo(db)
+	return NewCoordinator(db, &rules, writer)
+}
+
+func rolloutStatus(t *testing.T, db *sqlx.DB, rolloutId string) RolloutStatus {
+	t.Helper()
+	var status string
+	if err := db.Get(&status, `SELECT status FROM ingestion_rule_rollouts WHERE id = $1`, rolloutId); err != nil {
+		t.Fatalf("failed to load rollout status for %s: %v", rolloutId, err)
+	}
+	return RolloutStatus(status)
+}
+
+// TestDeployNextBatch_ScopesToBatchOnly is a regression test for the bug
+// where deployNextBatch marked every rule of the rollout's rule_type as
+// Deploying instead of just the batch it actually handed to the writer.
+func TestDeployNextBatch_ScopesToBatchOnly(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	insertPendingRule(t, db, "r1", "t", 3)
+	insertPendingRule(t, db, "r2", "t", 2)
+	insertPendingRule(t, db, "r3", "t", 1)
+
+	writer := &fakeWriter{}
+	c := newTestCoordinator(db, writer)
+
+	rollout, err := c.Start(context.Background(), ingestionRules.IngestionRuleType("t"), TriggerManual, RolloutPolicy{Kind: Batched, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if rollout.CurrentBatchIds != "r1" {
+		t.Fatalf("expected first batch to be r1 (highest priority), got %q", rollout.CurrentBatchIds)
+	}
+	if got := ruleStatus(t, db, "r1"); got != ingestionRules.Deploying {
+		t.Errorf("r1 status = %s, want Deploying", got)
+	}
+	if got := ruleStatus(t, db, "r2"); got != ingestionRules.PendingDeploy {
+		t.Errorf("r2 status = %s, want PendingDeploy (batch of 1 must not touch it)", got)
+	}
+	if got := ruleStatus(t, db, "r3"); got != ingestionRules.PendingDeploy {
+		t.Errorf("r3 status = %s, want PendingDeploy (batch of 1 must not touch it)", got)
+	}
+}
+
+// TestReportBatchResult_FailureScopedToCurrentBatch is a regression test
+// for the bug where a rejected batch marked every row Failed via
+// UpdateStatusBySeq, including earlier batches that had already deployed
+// successfully.
+func TestReportBatchResult_FailureScopedToCurrentBatch(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	insertPendingRule(t, db, "r1", "t", 3)
+	insertPendingRule(t, db, "r2", "t", 2)
+	insertPendingRule(t, db, "r3", "t", 1)
+
+	writer := &fakeWriter{failOn: map[int]bool{1: true}}
+	c := newTestCoordinator(db, writer)
+	ctx := context.Background()
+
+	rollout, err := c.Start(ctx, ingestionRules.IngestionRuleType("t"), TriggerManual, RolloutPolicy{Kind: Batched, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if err := c.ReportBatchResult(ctx, rollout.Id, true, ""); err != nil {
+		t.Fatalf("ReportBatchResult(success) returned error: %v", err)
+	}
+
+	if got := ruleStatus(t, db, "r1"); got != ingestionRules.Deployed {
+		t.Fatalf("r1 status = %s, want Deployed after first batch succeeds", got)
+	}
+
+	if err := c.ReportBatchResult(ctx, rollout.Id, false, "boom"); err != nil {
+		t.Fatalf("ReportBatchResult(failure) returned error: %v", err)
+	}
+
+	if got := ruleStatus(t, db, "r1"); got != ingestionRules.Deployed {
+		t.Errorf("r1 status = %s, want still Deployed - a later batch's failure must not roll it back", got)
+	}
+	if got := ruleStatus(t, db, "r2"); got != ingestionRules.Failed {
+		t.Errorf("r2 status = %s, want Failed", got)
+	}
+	if got := ruleStatus(t, db, "r3"); got != ingestionRules.Blocked {
+		t.Errorf("r3 status = %s, want Blocked (not-yet-attempted rules pause behind the failure)", got)
+	}
+}
+
+// TestRollback_OnlyAbortsTheIngestionRuleRollout is a regression test for
+// Rollback unconditionally reaching into an unrelated pipelines.Repo to
+// restore a pipeline config version: this coordinator only ever manages
+// ingestion rule rollouts, so Rollback must leave already-failed/blocked
+// rule rows exactly as Abort would, with no other side effects.
+func TestRollback_OnlyAbortsTheIngestionRuleRollout(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	insertPendingRule(t, db, "r1", "t", 2)
+	insertPendingRule(t, db, "r2", "t", 1)
+
+	writer := &fakeWriter{failOn: map[int]bool{0: true}}
+	c := newTestCoordinator(db, writer)
+	ctx := context.Background()
+
+	rollout, err := c.Start(ctx, ingestionRules.IngestionRuleType("t"), TriggerManual, RolloutPolicy{Kind: Batched, BatchSize: 1})
+	if err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	if got := rolloutStatus(t, db, rollout.Id); got != RolloutPaused {
+		t.Fatalf("rollout status = %s, want Paused after the first batch fails", got)
+	}
+
+	if err := c.Rollback(ctx, rollout.Id); err != nil {
+		t.Fatalf("Rollback returned error: %v", err)
+	}
+
+	if got := rolloutStatus(t, db, rollout.Id); got != RolloutAborted {
+		t.Errorf("rollout status = %s, want Aborted after Rollback", got)
+	}
+	if got := ruleStatus(t, db, "r1"); got != ingestionRules.Failed {
+		t.Errorf("r1 status = %s, want still Failed - Rollback only aborts the rollout, it doesn't touch rule rows", got)
+	}
+	if got := ruleStatus(t, db, "r2"); got != ingestionRules.Blocked {
+		t.Errorf("r2 status = %s, want still Blocked - Rollback only aborts the rollout, it doesn't touch rule rows", got)
+	}
+}
@@ -0,0 +1,82 @@
+package coordinator
+
+// Trigger records what caused a rollout to start, so the rollout history
+// can tell a manual redeploy apart from one kicked off by a cron
+// schedule or an edit to a rule.
+type Trigger string
+
+const (
+	TriggerManual Trigger = "manual"
+	TriggerCron   Trigger = "cron"
+	TriggerOnEdit Trigger = "on_edit"
+)
+
+// RolloutPolicyKind selects how a rollout's batches are shaped.
+type RolloutPolicyKind string
+
+const (
+	// AllAtOnce deploys every pending rule in a single batch, same as
+	// today's MarkDeploying.
+	AllAtOnce RolloutPolicyKind = "all_at_once"
+	// Canary deploys one rule at a time, at increasing sequence numbers,
+	// and waits for a success report before moving to the next rule.
+	Canary RolloutPolicyKind = "canary"
+	// Batched deploys BatchSize rules at a time.
+	Batched RolloutPolicyKind = "batched"
+)
+
+// RolloutPolicy describes how a Coordinator should batch pending rules
+// for a rollout.
+type RolloutPolicy struct {
+	Kind RolloutPolicyKind
+	// BatchSize is only used when Kind is Batched; Canary behaves as
+	// Batched(1) and AllAtOnce ignores it.
+	BatchSize int
+}
+
+// batchSize returns the effective batch size for the policy.
+func (p RolloutPolicy) batchSize(total int) int {
+	switch p.Kind {
+	case Canary:
+		return 1
+	case Batched:
+		if p.BatchSize > 0 {
+			return p.BatchSize
+		}
+		return total
+	default:
+		return total
+	}
+}
+
+// RolloutStatus is the lifecycle state of a Rollout row.
+type RolloutStatus string
+
+const (
+	RolloutRunning   RolloutStatus = "RUNNING"
+	RolloutPaused    RolloutStatus = "PAUSED"
+	RolloutCompleted RolloutStatus = "COMPLETED"
+	RolloutAborted   RolloutStatus = "ABORTED"
+)
+
+// Rollout is the persisted state of a single rollout run: which rules it
+// covers, what triggered it, which policy it's using and where it
+// currently is.
+type Rollout struct {
+	Id              string  `db:"id"`
+	RuleType        string  `db:"rule_type"`
+	Trigger         Trigger `db:"trigger"`
+	PolicyKind      string  `db:"policy_kind"`
+	BatchSize       int     `db:"batch_size"`
+	CurrentSequence int     `db:"current_sequence"`
+	// CurrentBatchIds is the comma-joined set of ingestion_rules ids in
+	// the batch currently being deployed. Status transitions scope to
+	// these ids rather than to rule_type or deployment_sequence alone,
+	// so one rollout's batch can never flip rows that belong to a
+	// different batch (an already-deployed earlier batch, a
+	// not-yet-attempted later one, or a concurrent rollout of the same
+	// rule type that happens to be on the same sequence number).
+	CurrentBatchIds string        `db:"current_batch_ids"`
+	Status          RolloutStatus `db:"status"`
+	ErrorMessage    string        `db:"error_message"`
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/ingestionRules/postgres"
 	"go.signoz.io/signoz/ee/query-service/ingestionRules/sqlite"
 	"go.signoz.io/signoz/ee/query-service/model"
 	"go.uber.org/zap"
@@ -29,7 +30,21 @@ func NewRepo(db *sqlx.DB) Repo {
 func (r *Repo) InitDB(engine string) error {
 	switch engine {
 	case "sqlite3", "sqlite":
-		return sqlite.InitDB(r.db)
+		if err := sqlite.InitDB(r.db); err != nil {
+			return err
+		}
+		if err := sqlite.InitDeployHistory(r.db); err != nil {
+			return err
+		}
+		return sqlite.InitRollouts(r.db)
+	case "postgres":
+		if err := postgres.InitDB(r.db); err != nil {
+			return err
+		}
+		if err := postgres.InitDeployHistory(r.db); err != nil {
+			return err
+		}
+		return postgres.InitRollouts(r.db)
 	default:
 		return fmt.Errorf("unsupported db")
 	}
@@ -166,6 +181,41 @@ func (r *Repo) GetDropRules(ctx context.Context) ([]IngestionRule, []error) {
 	return dropRules, errors
 }
 
+// GetRulesByTypeAndStatus returns rules of the given ruleType in the
+// given deployment status. Unlike GetDropRulesByStatus (a holdover from
+// when drop rules were the only rule type and so hardcodes rule_type to
+// IngestionRuleTypeDrop), this is parameterized by ruleType and is the
+// right call for any API that takes ruleType as an argument.
+func (r *Repo) GetRulesByTypeAndStatus(ctx context.Context, ruleType IngestionRuleType, s DeployStatus) ([]IngestionRule, []error) {
+	var errors []error
+	rules := []IngestionRule{}
+
+	query := `SELECT id,
+		source,
+		priority,
+		rule_type,
+		rule_subtype,
+		name,
+		config_json,
+		deployment_status,
+		deployment_sequence
+		FROM ingestion_rules
+		WHERE rule_type=$1 AND deployment_status=$2`
+
+	err := r.db.SelectContext(ctx, &rules, query, ruleType, s)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to get ingestion rules from db: %v", err)}
+	}
+
+	for _, d := range rules {
+		if err := d.parseConfig(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+
+	return rules, errors
+}
+
 func (r *Repo) GetDropRulesByStatus(ctx context.Context, s DeployStatus) ([]IngestionRule, []error) {
 	var errors []error
 	dropRules := []IngestionRule{}
@@ -271,10 +321,10 @@ func (r *Repo) MarkDeploying(ctx context.Context, seq int, ruleType IngestionRul
 func (r *Repo) UpdateStatusBySeq(ctx context.Context, seq int, status DeployStatus, errorMessage string) error {
 	// marks deploying rules with given seq as FAILED and e
 	updateQuery := `UPDATE ingestion_rules
-	set deployment_status = $1, 
+	set deployment_status = $1,
 	updated_at = $2,
 	error_message = $3
-	WHERE deployment_sequence=$3`
+	WHERE deployment_sequence=$4`
 
 	_, err := r.db.ExecContext(ctx, updateQuery, string(status), time.Now(), errorMessage, seq)
 	if err != nil {
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/exportutil"
+)
+
+// ImportMode controls how ImportBundle reconciles a bundle with the
+// current rule set.
+type ImportMode string
+
+const (
+	// ImportReplace deletes rules that aren't in the bundle before
+	// applying it.
+	ImportReplace ImportMode = "replace"
+	// ImportMerge only adds/updates rules from the bundle, leaving rules
+	// absent from the bundle untouched.
+	ImportMerge ImportMode = "merge"
+	// ImportDryRun applies no changes; the caller gets back the diff
+	// that would result from a merge import.
+	ImportDryRun ImportMode = "dry-run"
+)
+
+// Rule is the subset of an ingestion rule row shipped in a bundle.
+type Rule struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	Source      string `json:"source"`
+	RuleType    string `json:"ruleType"`
+	RuleSubType string `json:"ruleSubType"`
+	Priority    int    `json:"priority"`
+	RawConfig   string `json:"config"`
+}
+
+// Bundle is the full, signed export of an ingestion rule set. Rules
+// carry their source id so a re-import of the same bundle is
+// idempotent.
+type Bundle struct {
+	Rules      []Rule    `json:"rules"`
+	ExportedAt time.Time `json:"exportedAt"`
+	Signature  string    `json:"signature"`
+}
+
+// Sign computes and sets b.Signature to an HMAC-SHA256 digest of the
+// bundle contents, keyed on signingKey. Call this right before
+// marshaling the bundle for export.
+func (b *Bundle) Sign(signingKey string) error {
+	b.Signature = ""
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal bundle for signing")
+	}
+	b.Signature = exportutil.Digest(payload, signingKey)
+	return nil
+}
+
+// Verify reports whether b.Signature matches the bundle contents for
+// signingKey. Callers must reject an import whose signature doesn't
+// verify - it means the bundle was tampered with or signed with a
+// different key, and promoting it between environments would be unsafe.
+func (b *Bundle) Verify(signingKey string) error {
+	want := b.Signature
+	b.Signature = ""
+	defer func() { b.Signature = want }()
+
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal bundle for signing")
+	}
+
+	return exportutil.VerifyDigest(payload, signingKey, want)
+}
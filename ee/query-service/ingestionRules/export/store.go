@@ -0,0 +1,18 @@
+package export
+
+import "go.signoz.io/signoz/ee/query-service/exportutil"
+
+// ObjectStore, S3ObjectStore and S3Config are aliases onto exportutil's
+// object-storage plumbing, which is shared with pipelines/export rather
+// than duplicated per package.
+type (
+	ObjectStore   = exportutil.ObjectStore
+	S3ObjectStore = exportutil.S3ObjectStore
+	S3Config      = exportutil.S3Config
+)
+
+// NewS3ObjectStore builds an ObjectStore backed by an S3-compatible
+// bucket from the given config.
+func NewS3ObjectStore(cfg S3Config) (*S3ObjectStore, error) {
+	return exportutil.NewS3ObjectStore(cfg)
+}
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// InitDB creates the ingestion_rules schema on a Postgres database. It is
+// the Postgres counterpart of ingestionRules/sqlite.InitDB, used when the
+// query-service is pointed at a managed Postgres instance instead of the
+// bundled SQLite file.
+func InitDB(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingestion_rules (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		source TEXT,
+		rule_type TEXT NOT NULL,
+		rule_subtype TEXT,
+		priority INTEGER NOT NULL DEFAULT 1,
+		config_json JSONB NOT NULL,
+		deployment_status TEXT NOT NULL DEFAULT 'DIRTY',
+		deployment_sequence INTEGER NOT NULL DEFAULT -1,
+		error_message TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rules table")
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_ingestion_rules_config_json
+		ON ingestion_rules USING GIN (config_json)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rules config_json gin index")
+	}
+
+	return nil
+}
+
+// InitDeployHistory creates the Postgres counterpart of the
+// ingestion_rule_deploy_history table, with a FK back to ingestion_rules
+// so history rows are cleaned up if the rule itself is deleted.
+func InitDeployHistory(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingestion_rule_deploy_history (
+		id TEXT PRIMARY KEY,
+		rule_id TEXT NOT NULL REFERENCES ingestion_rules(id) ON DELETE CASCADE,
+		deployment_sequence INTEGER NOT NULL,
+		deployment_status TEXT NOT NULL,
+		error_message TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rule_deploy_history table")
+	}
+	return nil
+}
+
+// InitRollouts creates the Postgres counterpart of the
+// ingestion_rule_rollouts table used by the deployment-coordinator.
+func InitRollouts(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingestion_rule_rollouts (
+		id TEXT PRIMARY KEY,
+		rule_type TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		policy_kind TEXT NOT NULL,
+		batch_size INTEGER NOT NULL DEFAULT 0,
+		current_sequence INTEGER NOT NULL DEFAULT 0,
+		current_batch_ids TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error_message TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rule_rollouts table")
+	}
+	return nil
+}
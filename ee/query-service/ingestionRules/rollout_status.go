@@ -0,0 +1,7 @@
+package ingestionRules
+
+// Blocked marks a rule whose batch was queued behind one that failed.
+// The deployment-coordinator sets this on every not-yet-deployed rule in
+// a rollout once it auto-pauses, so operators can see at a glance which
+// rules are stuck waiting on a Resume/Abort/Rollback decision.
+const Blocked DeployStatus = "BLOCKED"
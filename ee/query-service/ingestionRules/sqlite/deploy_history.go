@@ -0,0 +1,26 @@
+package sqlite
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// InitDeployHistory creates the table used to track the per-rule
+// deployment status transitions DeploymentController makes (reset to
+// pending and redeploying on rerun, skipped on skip) so the UI can show
+// prior attempts for a rule even after it has been rerun or skipped.
+// Transitions made outside DeploymentController are not recorded here.
+func InitDeployHistory(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingestion_rule_deploy_history (
+		id TEXT PRIMARY KEY,
+		rule_id TEXT NOT NULL,
+		deployment_sequence INTEGER NOT NULL,
+		deployment_status TEXT NOT NULL,
+		error_message TEXT,
+		created_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rule_deploy_history table")
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package sqlite
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// InitRollouts creates the table the deployment-coordinator uses to
+// persist in-flight rollouts (trigger, policy, current batch) so a
+// paused or canary rollout survives a query-service restart.
+func InitRollouts(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS ingestion_rule_rollouts (
+		id TEXT PRIMARY KEY,
+		rule_type TEXT NOT NULL,
+		trigger TEXT NOT NULL,
+		policy_kind TEXT NOT NULL,
+		batch_size INTEGER NOT NULL DEFAULT 0,
+		current_sequence INTEGER NOT NULL DEFAULT 0,
+		current_batch_ids TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error_message TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create ingestion_rule_rollouts table")
+	}
+	return nil
+}
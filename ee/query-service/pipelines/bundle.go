@@ -0,0 +1,246 @@
+package pipelines
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/model"
+	"go.signoz.io/signoz/ee/query-service/pipelines/export"
+	"go.uber.org/zap"
+)
+
+// ExportBundle serializes every pipeline, along with the
+// agent_config_versions row they're currently pinned to, into a single
+// bundle written to w and signed with signingKey. The bundle is meant to
+// be pushed to object storage and later replayed with ImportBundle
+// against another environment using the same signingKey.
+func (r *Repo) ExportBundle(ctx context.Context, w io.Writer, signingKey string) error {
+	pipelines := []model.Pipeline{}
+	if err := r.db.SelectContext(ctx, &pipelines, `SELECT id, order_id, enabled, name, alias, filter, config_json, deployment_status, deployment_sequence FROM pipelines`); err != nil {
+		zap.S().Errorf("failed to load pipelines for export", err)
+		return errors.Wrap(err, "failed to load pipelines for export")
+	}
+
+	versions, apiErr := r.ListVersions(ctx, logPipelines)
+	if apiErr != nil {
+		return errors.New(apiErr.Error())
+	}
+
+	bundle := &export.Bundle{Pipelines: pipelines}
+	if len(versions) > 0 {
+		v := versions[0]
+		bundle.Version = &export.VersionExport{
+			Id:          v.Id,
+			Version:     v.Version,
+			ElementType: v.ElementType,
+			CreatedBy:   v.CreatedBy,
+			CreatedAt:   v.CreatedAt,
+			Reason:      v.Reason,
+		}
+	}
+
+	if err := bundle.Sign(signingKey); err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(bundle)
+}
+
+// ImportBundle reconciles the pipeline set in r with the one described
+// by the bundle read from r2, according to mode. ImportDryRun applies no
+// changes and returns the diff that a merge import would produce;
+// ImportReplace and ImportMerge apply the bundle and return the
+// resulting diff against the pre-import state.
+//
+// Import is idempotent: bundle pipelines carry their source id, so
+// importing the same bundle twice leaves the pipeline set unchanged the
+// second time - a no-op reimport records no new agent_config_versions
+// row either. All deletes, inserts, updates and the version snapshot are
+// applied in a single transaction so a failure partway through never
+// leaves a half-applied bundle.
+func (r *Repo) ImportBundle(ctx context.Context, r2 io.Reader, mode export.ImportMode, signingKey string) (*VersionDiff, error) {
+	bundle := &export.Bundle{}
+	if err := json.NewDecoder(r2).Decode(bundle); err != nil {
+		return nil, errors.Wrap(err, "failed to decode bundle")
+	}
+
+	if err := bundle.Verify(signingKey); err != nil {
+		return nil, err
+	}
+
+	current := []model.Pipeline{}
+	if err := r.db.SelectContext(ctx, &current, `SELECT id, order_id, enabled, name, alias, filter, config_json FROM pipelines`); err != nil {
+		return nil, errors.Wrap(err, "failed to load current pipelines for import")
+	}
+
+	currentById := make(map[string]model.Pipeline, len(current))
+	for _, p := range current {
+		currentById[p.Id] = p
+	}
+
+	diff := &VersionDiff{}
+	for _, incoming := range bundle.Pipelines {
+		existing, ok := currentById[incoming.Id]
+		if !ok {
+			diff.Added = append(diff.Added, incoming)
+			continue
+		}
+		if existing.RawConfig != incoming.RawConfig {
+			patch, err := jsonpatch.CreateMergePatch([]byte(existing.RawConfig), []byte(incoming.RawConfig))
+			if err != nil {
+				zap.S().Errorf("failed to diff pipeline config_json during import", err)
+			}
+
+			diff.Modified = append(diff.Modified, PipelineModification{
+				PipelineId: incoming.Id,
+				Name:       incoming.Name,
+				Patch:      patch,
+			})
+		}
+	}
+
+	if mode == export.ImportReplace {
+		bundledIds := make(map[string]bool, len(bundle.Pipelines))
+		for _, p := range bundle.Pipelines {
+			bundledIds[p.Id] = true
+		}
+		for _, p := range current {
+			if !bundledIds[p.Id] {
+				diff.Removed = append(diff.Removed, p)
+			}
+		}
+	}
+
+	if mode == export.ImportDryRun {
+		return diff, nil
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start import transaction")
+	}
+	defer tx.Rollback()
+
+	if mode == export.ImportReplace {
+		for _, removed := range diff.Removed {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM pipelines WHERE id = $1`, removed.Id); err != nil {
+				return nil, errors.Wrap(err, "failed to delete pipeline removed by import")
+			}
+		}
+	}
+
+	for _, incoming := range bundle.Pipelines {
+		if _, ok := currentById[incoming.Id]; !ok {
+			// Insert with the bundle's own id so a later re-import of the
+			// same bundle recognizes it as already applied.
+			insertQuery := `INSERT INTO pipelines
+				(id, order_id, enabled, name, alias, filter, config_json, deployment_status, deployment_sequence)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+			if _, err := tx.ExecContext(ctx, insertQuery,
+				incoming.Id, incoming.OrderId, incoming.Enabled, incoming.Name, incoming.Alias, incoming.Filter,
+				incoming.RawConfig, PendingDeploy, -2); err != nil {
+				return nil, errors.Wrap(err, "failed to import pipeline")
+			}
+			continue
+		}
+
+		if currentById[incoming.Id].RawConfig == incoming.RawConfig {
+			// Already applied, nothing to do - this is what keeps
+			// re-importing the same bundle idempotent.
+			continue
+		}
+
+		updateQuery := `UPDATE pipelines
+			SET order_id = $1, enabled = $2, name = $3, alias = $4, filter = $5,
+			config_json = $6, deployment_status = $7, deployment_sequence = $8
+			WHERE id = $9`
+
+		if _, err := tx.ExecContext(ctx, updateQuery,
+			incoming.OrderId, incoming.Enabled, incoming.Name, incoming.Alias, incoming.Filter,
+			incoming.RawConfig, PendingDeploy, -2, incoming.Id); err != nil {
+			return nil, errors.Wrap(err, "failed to update modified pipeline during import")
+		}
+	}
+
+	noop := len(diff.Added) == 0 && len(diff.Modified) == 0 && len(diff.Removed) == 0
+
+	if bundle.Version != nil && !noop {
+		newVersionId := uuid.New().String()
+
+		versionQuery := `INSERT INTO agent_config_versions
+			(id, element_type, created_by, created_at, reason, version)
+			VALUES ($1, $2, $3, $4, $5, (SELECT COALESCE(MAX(version), 0) + 1 FROM agent_config_versions WHERE element_type = $2))`
+
+		if _, err := tx.ExecContext(ctx, versionQuery,
+			newVersionId, logPipelines, bundle.Version.CreatedBy, bundle.Version.CreatedAt, bundle.Version.Reason); err != nil {
+			return nil, errors.Wrap(err, "failed to record imported pipeline version")
+		}
+
+		// Link every pipeline that's live after this import to the new
+		// version row, the same way RollbackToVersion does - otherwise the
+		// version is an orphan with nothing ListVersions/GetVersionDiff can
+		// resolve it against.
+		liveIds := make(map[string]bool, len(bundle.Pipelines))
+		for _, p := range bundle.Pipelines {
+			liveIds[p.Id] = true
+		}
+		if mode != export.ImportReplace {
+			for _, p := range current {
+				liveIds[p.Id] = true
+			}
+		}
+
+		elementQuery := `INSERT INTO agent_config_elements
+			(id, version_id, element_id, element_type)
+			VALUES ($1, $2, $3, $4)`
+
+		for id := range liveIds {
+			if _, err := tx.ExecContext(ctx, elementQuery, uuid.New().String(), newVersionId, id, logPipelines); err != nil {
+				return nil, errors.Wrap(err, "failed to link imported pipeline to new version")
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "failed to commit import transaction")
+	}
+
+	return diff, nil
+}
+
+// PushBundle exports the current pipeline set and uploads it to an
+// object store under key, for GitOps-style promotion between
+// environments.
+func (r *Repo) PushBundle(ctx context.Context, store export.ObjectStore, key string, signingKey string) error {
+	pr, pw := io.Pipe()
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- r.ExportBundle(ctx, pw, signingKey)
+		pw.Close()
+	}()
+
+	if err := store.Put(ctx, key, pr); err != nil {
+		return err
+	}
+
+	return <-errCh
+}
+
+// PullBundle downloads a bundle from key in an object store and applies
+// it to r according to mode. This gives operators a disaster-recovery
+// path that doesn't depend on the SQLite file surviving.
+func (r *Repo) PullBundle(ctx context.Context, store export.ObjectStore, key string, mode export.ImportMode, signingKey string) (*VersionDiff, error) {
+	body, err := store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return r.ImportBundle(ctx, body, mode, signingKey)
+}
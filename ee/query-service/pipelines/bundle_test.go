@@ -0,0 +1,114 @@
+package pipelines
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.signoz.io/signoz/ee/query-service/model"
+	"go.signoz.io/signoz/ee/query-service/pipelines/export"
+)
+
+const testSigningKey = "test-signing-key"
+
+func encodeSignedBundle(t *testing.T, bundle *export.Bundle) *bytes.Buffer {
+	t.Helper()
+	if err := bundle.Sign(testSigningKey); err != nil {
+		t.Fatalf("failed to sign bundle: %v", err)
+	}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(bundle); err != nil {
+		t.Fatalf("failed to encode bundle: %v", err)
+	}
+	return buf
+}
+
+func countVersions(t *testing.T, repo *Repo) int {
+	t.Helper()
+	var count int
+	if err := repo.db.Get(&count, `SELECT COUNT(*) FROM agent_config_versions WHERE element_type = $1`, logPipelines); err != nil {
+		t.Fatalf("failed to count versions: %v", err)
+	}
+	return count
+}
+
+// TestImportBundle_NoopReimportRecordsNoNewVersion asserts the
+// idempotency guarantee documented on ImportBundle: reimporting a
+// bundle that matches the current pipeline set exactly must not grow
+// agent_config_versions, since nothing actually changed.
+func TestImportBundle_NoopReimportRecordsNoNewVersion(t *testing.T) {
+	repo := newVersionsTestRepo(t)
+	ctx := context.Background()
+
+	seedPipeline(t, ctx, &repo, "p1", `{"op":"v1"}`)
+
+	bundle := &export.Bundle{
+		Pipelines: []model.Pipeline{
+			{Id: "p1", Name: "p1", RawConfig: `{"op":"v1"}`},
+		},
+		Version: &export.VersionExport{
+			CreatedBy: "operator",
+			CreatedAt: time.Now(),
+			Reason:    "reimport",
+		},
+	}
+
+	diff, err := repo.ImportBundle(ctx, encodeSignedBundle(t, bundle), export.ImportMerge, testSigningKey)
+	if err != nil {
+		t.Fatalf("ImportBundle returned error: %v", err)
+	}
+
+	if len(diff.Added) != 0 || len(diff.Modified) != 0 || len(diff.Removed) != 0 {
+		t.Fatalf("expected an empty diff for a pure reimport, got %+v", diff)
+	}
+
+	if got := countVersions(t, &repo); got != 0 {
+		t.Fatalf("expected no version to be recorded for a no-op reimport, got %d", got)
+	}
+}
+
+// TestImportBundle_LinksNewVersionToImportedPipelines is a regression
+// test for ImportBundle recording an agent_config_versions row with no
+// matching agent_config_elements links, leaving it an orphan that
+// ListVersions/GetVersionDiff can't resolve against.
+func TestImportBundle_LinksNewVersionToImportedPipelines(t *testing.T) {
+	repo := newVersionsTestRepo(t)
+	ctx := context.Background()
+
+	seedPipeline(t, ctx, &repo, "p1", `{"op":"v1"}`)
+
+	bundle := &export.Bundle{
+		Pipelines: []model.Pipeline{
+			{Id: "p1", Name: "p1", RawConfig: `{"op":"v2"}`},
+		},
+		Version: &export.VersionExport{
+			CreatedBy: "operator",
+			CreatedAt: time.Now(),
+			Reason:    "import",
+		},
+	}
+
+	diff, err := repo.ImportBundle(ctx, encodeSignedBundle(t, bundle), export.ImportMerge, testSigningKey)
+	if err != nil {
+		t.Fatalf("ImportBundle returned error: %v", err)
+	}
+
+	if len(diff.Modified) != 1 {
+		t.Fatalf("expected exactly one modified pipeline, got %+v", diff.Modified)
+	}
+	if diff.Modified[0].Patch == nil {
+		t.Fatalf("expected diff.Modified[0].Patch to be populated, got nil")
+	}
+
+	var linkedCount int
+	if err := repo.db.Get(&linkedCount, `SELECT COUNT(*) FROM agent_config_elements e
+		JOIN agent_config_versions v ON v.id = e.version_id
+		WHERE v.element_type = $1`, logPipelines); err != nil {
+		t.Fatalf("failed to count linked elements: %v", err)
+	}
+	if linkedCount != 1 {
+		t.Fatalf("expected the new version to link exactly one pipeline, got %d", linkedCount)
+	}
+}
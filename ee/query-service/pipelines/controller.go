@@ -0,0 +1,66 @@
+package pipelines
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// DeploymentController drives rerun/skip recovery for pipeline
+// deployments on top of Repo, matching the recovery API added for
+// ingestionRules.Repo: a failed deploy sequence can be retried in full
+// or have its broken pipelines skipped so the rest of the set still
+// ships.
+type DeploymentController struct {
+	repo *Repo
+}
+
+// NewDeploymentController wires a DeploymentController to an existing
+// pipelines Repo.
+func NewDeploymentController(repo *Repo) *DeploymentController {
+	return &DeploymentController{repo: repo}
+}
+
+// RerunFailed resets every pipeline currently marked Failed back to
+// PendingDeploy so the agent-config reconciler picks it up again, using
+// the same deployment_sequence = -2 sentinel as the ingestionRules
+// rerun path.
+func (c *DeploymentController) RerunFailed(ctx context.Context) error {
+	resetQuery := `UPDATE pipelines
+	SET deployment_status = $1,
+	deployment_sequence = $2,
+	error_message = ''
+	WHERE deployment_status = $3`
+
+	_, err := c.repo.db.ExecContext(ctx, resetQuery, PendingDeploy, -2, Failed)
+	if err != nil {
+		zap.S().Errorf("failed to reset failed pipelines for rerun", err)
+		return errors.Wrap(err, "failed to reset failed pipelines for rerun")
+	}
+
+	return nil
+}
+
+// SkipFailed marks the given pipeline ids as Skipped so they are
+// excluded from the next deploy cycle. Rows are retained, not deleted.
+func (c *DeploymentController) SkipFailed(ctx context.Context, pipelineIDs ...string) error {
+	if len(pipelineIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`UPDATE pipelines SET deployment_status = ? WHERE id IN (?)`, Skipped, pipelineIDs)
+	if err != nil {
+		return errors.Wrap(err, "failed to build skip query")
+	}
+
+	_, err = c.repo.db.ExecContext(ctx, c.repo.db.Rebind(query), args...)
+	if err != nil {
+		zap.S().Errorf("failed to mark pipelines as skipped", err)
+		return errors.Wrap(err, "failed to mark pipelines as skipped")
+	}
+
+	return nil
+}
+
@@ -9,6 +9,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/pkg/errors"
 	"go.signoz.io/signoz/ee/query-service/model"
+	"go.signoz.io/signoz/ee/query-service/pipelines/postgres"
 	"go.signoz.io/signoz/ee/query-service/pipelines/sqlite"
 	"go.uber.org/zap"
 )
@@ -30,7 +31,15 @@ func NewRepo(db *sqlx.DB) Repo {
 func (r *Repo) InitDB(engine string) error {
 	switch engine {
 	case "sqlite3", "sqlite":
-		return sqlite.InitDB(r.db)
+		if err := sqlite.InitDB(r.db); err != nil {
+			return err
+		}
+		if err := sqlite.AddVersionAuditColumns(r.db); err != nil {
+			return err
+		}
+		return sqlite.AddErrorMessageColumn(r.db)
+	case "postgres":
+		return postgres.InitDB(r.db)
 	default:
 		return fmt.Errorf("unsupported db")
 	}
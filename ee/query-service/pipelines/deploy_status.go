@@ -0,0 +1,16 @@
+package pipelines
+
+// DeployStatus tracks where a pipeline is in its rollout to the agent,
+// mirroring the status values ingestionRules.DeployStatus already uses.
+type DeployStatus string
+
+const (
+	PendingDeploy DeployStatus = "DIRTY"
+	Deploying     DeployStatus = "DEPLOYING"
+	Deployed      DeployStatus = "DEPLOYED"
+	Failed        DeployStatus = "FAILED"
+	// Skipped marks a pipeline an operator explicitly skipped during a
+	// rerun so it is excluded from the next deploy cycle while the rest
+	// of the set still goes out. The row is retained for audit.
+	Skipped DeployStatus = "SKIPPED"
+)
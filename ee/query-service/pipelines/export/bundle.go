@@ -0,0 +1,78 @@
+package export
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.signoz.io/signoz/ee/query-service/exportutil"
+	"go.signoz.io/signoz/ee/query-service/model"
+)
+
+// ImportMode controls how ImportBundle reconciles a bundle with the
+// current pipeline set.
+type ImportMode string
+
+const (
+	// ImportReplace deletes pipelines that aren't in the bundle before
+	// applying it.
+	ImportReplace ImportMode = "replace"
+	// ImportMerge only adds/updates pipelines from the bundle, leaving
+	// pipelines absent from the bundle untouched.
+	ImportMerge ImportMode = "merge"
+	// ImportDryRun applies no changes; the caller gets back the diff
+	// that would result from a merge import.
+	ImportDryRun ImportMode = "dry-run"
+)
+
+// Bundle is the full, signed export of a pipeline set: every pipeline
+// row plus the agent_config_versions row it is currently pinned to.
+// Pipelines carry their source id so a re-import of the same bundle is
+// idempotent.
+type Bundle struct {
+	Pipelines  []model.Pipeline `json:"pipelines"`
+	Version    *VersionExport   `json:"version,omitempty"`
+	ExportedAt time.Time        `json:"exportedAt"`
+	Signature  string           `json:"signature"`
+}
+
+// VersionExport is the subset of agent_config_versions shipped in a
+// bundle.
+type VersionExport struct {
+	Id          string    `json:"id"`
+	Version     int       `json:"version"`
+	ElementType string    `json:"elementType"`
+	CreatedBy   string    `json:"createdBy"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Reason      string    `json:"reason"`
+}
+
+// Sign computes and sets b.Signature to an HMAC-SHA256 digest of the
+// bundle contents, keyed on signingKey. Call this right before
+// marshaling the bundle for export.
+func (b *Bundle) Sign(signingKey string) error {
+	b.Signature = ""
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal bundle for signing")
+	}
+	b.Signature = exportutil.Digest(payload, signingKey)
+	return nil
+}
+
+// Verify reports whether b.Signature matches the bundle contents for
+// signingKey. Callers must reject an import whose signature doesn't
+// verify - it means the bundle was tampered with or signed with a
+// different key, and promoting it between environments would be unsafe.
+func (b *Bundle) Verify(signingKey string) error {
+	want := b.Signature
+	b.Signature = ""
+	defer func() { b.Signature = want }()
+
+	payload, err := json.Marshal(b)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal bundle for signing")
+	}
+
+	return exportutil.VerifyDigest(payload, signingKey, want)
+}
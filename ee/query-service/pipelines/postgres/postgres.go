@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// InitDB creates the pipelines schema (pipelines, agent_config_elements,
+// agent_config_versions) on a Postgres database. It is the Postgres
+// counterpart of pipelines/sqlite.InitDB, used when the query-service is
+// pointed at a managed Postgres instance instead of the bundled SQLite
+// file.
+func InitDB(db *sqlx.DB) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS agent_config_versions (
+		id TEXT PRIMARY KEY,
+		version INTEGER NOT NULL,
+		element_type TEXT NOT NULL,
+		deploy_status TEXT NOT NULL DEFAULT 'DIRTY',
+		deploy_sequence INTEGER NOT NULL DEFAULT -1,
+		created_by TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		reason TEXT
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create agent_config_versions table")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS pipelines (
+		id TEXT PRIMARY KEY,
+		order_id INTEGER NOT NULL DEFAULT 1,
+		enabled BOOLEAN NOT NULL DEFAULT true,
+		name TEXT NOT NULL,
+		alias TEXT,
+		filter TEXT,
+		config_json JSONB NOT NULL,
+		deployment_status TEXT NOT NULL DEFAULT 'DIRTY',
+		deployment_sequence INTEGER NOT NULL DEFAULT -1,
+		error_message TEXT,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pipelines table")
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_pipelines_config_json
+		ON pipelines USING GIN (config_json)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create pipelines config_json gin index")
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS agent_config_elements (
+		id TEXT PRIMARY KEY,
+		version_id TEXT NOT NULL REFERENCES agent_config_versions(id) ON DELETE CASCADE,
+		element_id TEXT NOT NULL REFERENCES pipelines(id) ON DELETE CASCADE,
+		element_type TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return errors.Wrap(err, "failed to create agent_config_elements table")
+	}
+
+	return nil
+}
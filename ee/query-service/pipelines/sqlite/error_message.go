@@ -0,0 +1,24 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// AddErrorMessageColumn adds error_message to pipelines, which RerunFailed
+// clears when it resets a failed pipeline, matching the column the new
+// Postgres schema defines from the start. ALTER TABLE ADD
+// COLUMN has no IF NOT EXISTS form in sqlite3, so a "duplicate column"
+// error from a prior run of this migration is treated as a no-op.
+func AddErrorMessageColumn(db *sqlx.DB) error {
+	_, err := db.Exec(`ALTER TABLE pipelines ADD COLUMN error_message TEXT`)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate column name") {
+			return nil
+		}
+		return errors.Wrap(err, "failed to add pipelines error_message column")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package sqlite
+
+import (
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// AddVersionAuditColumns adds created_by, created_at and reason to
+// agent_config_versions so rollbacks can record who triggered them and
+// why. ALTER TABLE ADD COLUMN has no IF NOT EXISTS form in sqlite3, so
+// a "duplicate column" error from a prior run of this migration is
+// treated as a no-op.
+func AddVersionAuditColumns(db *sqlx.DB) error {
+	columns := []string{
+		`ALTER TABLE agent_config_versions ADD COLUMN created_by TEXT`,
+		`ALTER TABLE agent_config_versions ADD COLUMN created_at TIMESTAMP`,
+		`ALTER TABLE agent_config_versions ADD COLUMN reason TEXT`,
+	}
+
+	for _, stmt := range columns {
+		if _, err := db.Exec(stmt); err != nil {
+			if strings.Contains(err.Error(), "duplicate column name") {
+				continue
+			}
+			return errors.Wrap(err, "failed to add agent_config_versions audit column")
+		}
+	}
+
+	return nil
+}
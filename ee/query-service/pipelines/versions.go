@@ -0,0 +1,204 @@
+package pipelines
+
+import (
+	"context"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/google/uuid"
+	"go.signoz.io/signoz/ee/query-service/model"
+	"go.uber.org/zap"
+)
+
+// Version is a row of agent_config_versions: a point-in-time snapshot of
+// the pipeline set for a given element type.
+type Version struct {
+	Id          string    `json:"id" db:"id"`
+	Version     int       `json:"version" db:"version"`
+	ElementType string    `json:"elementType" db:"element_type"`
+	CreatedBy   string    `json:"createdBy" db:"created_by"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	Reason      string    `json:"reason" db:"reason"`
+}
+
+// PipelineModification describes a pipeline whose config_json changed
+// between two versions. Patch is an RFC 7396 JSON merge patch from the
+// old config to the new one, so the UI can render a before/after without
+// shipping both full configs.
+type PipelineModification struct {
+	PipelineId string `json:"pipelineId"`
+	Name       string `json:"name"`
+	Patch      []byte `json:"patch"`
+}
+
+// VersionDiff is the structured result of comparing two pipeline
+// versions: pipelines only present in the newer version, pipelines only
+// present in the older one, and pipelines present in both whose
+// config_json differs.
+type VersionDiff struct {
+	Added    []model.Pipeline       `json:"added"`
+	Removed  []model.Pipeline       `json:"removed"`
+	Modified []PipelineModification `json:"modified"`
+}
+
+// ListVersions returns the known agent_config_versions rows for an
+// element type, most recent first, so operators can pick a rollback
+// target.
+func (r *Repo) ListVersions(ctx context.Context, elementType string) ([]Version, *model.ApiError) {
+	versions := []Version{}
+
+	query := `SELECT id, version, element_type, created_by, created_at, reason
+		FROM agent_config_versions
+		WHERE element_type = $1
+		ORDER BY version DESC`
+
+	if err := r.db.SelectContext(ctx, &versions, query, elementType); err != nil {
+		zap.S().Errorf("failed to list pipeline versions", err)
+		return nil, model.BadRequestStr("failed to list pipeline versions")
+	}
+
+	return versions, nil
+}
+
+// GetVersionDiff compares the pipeline sets tied to two agent_config
+// versions and returns what was added, removed and modified between
+// them.
+func (r *Repo) GetVersionDiff(ctx context.Context, vA, vB int) (*VersionDiff, *model.ApiError) {
+	pipelinesA, errs := r.getPipelinesByVersion(ctx, vA)
+	if len(errs) > 0 {
+		return nil, model.BadRequestStr("failed to load pipelines for version A")
+	}
+
+	pipelinesB, errs := r.getPipelinesByVersion(ctx, vB)
+	if len(errs) > 0 {
+		return nil, model.BadRequestStr("failed to load pipelines for version B")
+	}
+
+	byName := func(pipelines []model.Pipeline) map[string]model.Pipeline {
+		m := make(map[string]model.Pipeline, len(pipelines))
+		for _, p := range pipelines {
+			m[p.Name] = p
+		}
+		return m
+	}
+
+	setA, setB := byName(pipelinesA), byName(pipelinesB)
+	diff := &VersionDiff{}
+
+	for name, pb := range setB {
+		pa, ok := setA[name]
+		if !ok {
+			diff.Added = append(diff.Added, pb)
+			continue
+		}
+
+		if pa.RawConfig == pb.RawConfig {
+			continue
+		}
+
+		patch, err := jsonpatch.CreateMergePatch([]byte(pa.RawConfig), []byte(pb.RawConfig))
+		if err != nil {
+			zap.S().Errorf("failed to diff pipeline config_json", err)
+			continue
+		}
+
+		diff.Modified = append(diff.Modified, PipelineModification{
+			PipelineId: pb.Id,
+			Name:       name,
+			Patch:      patch,
+		})
+	}
+
+	for name, pa := range setA {
+		if _, ok := setB[name]; !ok {
+			diff.Removed = append(diff.Removed, pa)
+		}
+	}
+
+	return diff, nil
+}
+
+// RollbackToVersion promotes a past pipeline version back to current. It
+// never mutates history: it records a new agent_config_versions row, then
+// the target version's pipelines rows are copied under new ids and linked
+// to the new version, and deployment is kicked off by marking the
+// rolled-back pipelines PendingDeploy so the agent-config reconciler
+// ships them. The new version links only those copies; the pipeline set
+// that was current before the rollback is not copied into it or into any
+// other new version row. The version row, copies and links are all
+// written in a single transaction so a failure partway through never
+// leaves a half-promoted version on disk.
+func (r *Repo) RollbackToVersion(ctx context.Context, version int, createdBy, reason string) (*Version, *model.ApiError) {
+	target := []model.Pipeline{}
+	targetQuery := `SELECT r.id, r.order_id, r.enabled, r.name, r.alias, r.filter, r.config_json
+		FROM pipelines r, agent_config_elements e, agent_config_versions v
+		WHERE r.id = e.element_id
+		AND v.id = e.version_id
+		AND e.element_type = $1
+		AND v.version = $2`
+
+	if err := r.db.SelectContext(ctx, &target, targetQuery, logPipelines, version); err != nil {
+		zap.S().Errorf("failed to load target pipeline version", err)
+		return nil, model.BadRequestStr("failed to load target pipeline version")
+	}
+
+	newVersion := &Version{
+		Id:          uuid.New().String(),
+		ElementType: logPipelines,
+		CreatedBy:   createdBy,
+		CreatedAt:   time.Now(),
+		Reason:      reason,
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		zap.S().Errorf("failed to start rollback transaction", err)
+		return nil, model.BadRequestStr("failed to start rollback transaction")
+	}
+	defer tx.Rollback()
+
+	versionQuery := `INSERT INTO agent_config_versions
+		(id, element_type, created_by, created_at, reason, version)
+		VALUES ($1, $2, $3, $4, $5, (SELECT COALESCE(MAX(version), 0) + 1 FROM agent_config_versions WHERE element_type = $2))`
+
+	if _, err := tx.ExecContext(ctx, versionQuery,
+		newVersion.Id, newVersion.ElementType, newVersion.CreatedBy, newVersion.CreatedAt, newVersion.Reason); err != nil {
+		zap.S().Errorf("failed to record rollback version", err)
+		return nil, model.BadRequestStr("failed to record rollback version")
+	}
+
+	if err := tx.GetContext(ctx, &newVersion.Version, `SELECT version FROM agent_config_versions WHERE id = $1`, newVersion.Id); err != nil {
+		zap.S().Errorf("failed to read back snapshot version number", err)
+		return nil, model.BadRequestStr("failed to read back snapshot version number")
+	}
+
+	for _, p := range target {
+		newId := uuid.New().String()
+
+		insertQuery := `INSERT INTO pipelines
+			(id, order_id, enabled, name, alias, filter, config_json, deployment_status, deployment_sequence)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+		if _, err := tx.ExecContext(ctx, insertQuery,
+			newId, p.OrderId, p.Enabled, p.Name, p.Alias, p.Filter, p.RawConfig, PendingDeploy, -2); err != nil {
+			zap.S().Errorf("failed to copy pipeline during rollback", err)
+			return nil, model.BadRequestStr("failed to copy pipeline during rollback")
+		}
+
+		elementQuery := `INSERT INTO agent_config_elements
+			(id, version_id, element_id, element_type)
+			VALUES ($1, $2, $3, $4)`
+
+		if _, err := tx.ExecContext(ctx, elementQuery, uuid.New().String(), newVersion.Id, newId, logPipelines); err != nil {
+			zap.S().Errorf("failed to link rolled-back pipeline to new version", err)
+			return nil, model.BadRequestStr("failed to link rolled-back pipeline to new version")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		zap.S().Errorf("failed to commit rollback transaction", err)
+		return nil, model.BadRequestStr("failed to commit rollback transaction")
+	}
+
+	return newVersion, nil
+}
@@ -0,0 +1,137 @@
+package pipelines
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newVersionsTestRepo(t *testing.T) Repo {
+	t.Helper()
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	schema := []string{
+		`CREATE TABLE agent_config_versions (
+			id TEXT PRIMARY KEY,
+			version INTEGER NOT NULL,
+			element_type TEXT NOT NULL,
+			created_by TEXT,
+			created_at TIMESTAMP,
+			reason TEXT
+		)`,
+		`CREATE TABLE pipelines (
+			id TEXT PRIMARY KEY,
+			order_id INTEGER,
+			enabled BOOLEAN,
+			name TEXT,
+			alias TEXT,
+			filter TEXT,
+			config_json TEXT,
+			deployment_status TEXT,
+			deployment_sequence INTEGER
+		)`,
+		`CREATE TABLE agent_config_elements (
+			id TEXT PRIMARY KEY,
+			version_id TEXT NOT NULL,
+			element_id TEXT NOT NULL,
+			element_type TEXT NOT NULL
+		)`,
+	}
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("failed to create test schema: %v", err)
+		}
+	}
+
+	return NewRepo(db)
+}
+
+// TestRollbackToVersion_NeverMutatesHistory asserts RollbackToVersion's
+// own documented guarantee: the target version's pipelines row stays
+// untouched, and the rollback is recorded as a brand new version rather
+// than rewriting the one being rolled back to.
+func TestRollbackToVersion_NeverMutatesHistory(t *testing.T) {
+	repo := newVersionsTestRepo(t)
+	ctx := context.Background()
+
+	seedPipeline(t, ctx, &repo, "p1", `{"op":"v1"}`)
+	seedVersion(t, ctx, &repo, "v1", 1)
+	linkPipelineToVersion(t, ctx, &repo, "p1", "v1")
+
+	// Each version links its own pipelines rows, so the v1 row keeps its
+	// config once v2 is saved.
+	seedPipeline(t, ctx, &repo, "p2", `{"op":"v2"}`)
+	seedVersion(t, ctx, &repo, "v2", 2)
+	linkPipelineToVersion(t, ctx, &repo, "p2", "v2")
+
+	newVersion, apiErr := repo.RollbackToVersion(ctx, 1, "operator", "rolling back a bad change")
+	if apiErr != nil {
+		t.Fatalf("RollbackToVersion returned error: %v", apiErr)
+	}
+
+	if newVersion.Version != 3 {
+		t.Fatalf("expected rollback to create version 3, got %d", newVersion.Version)
+	}
+
+	var currentConfig string
+	if err := repo.db.Get(&currentConfig, `SELECT config_json FROM pipelines WHERE id = 'p2'`); err != nil {
+		t.Fatalf("failed to read original p2 row: %v", err)
+	}
+	if currentConfig != `{"op":"v2"}` {
+		t.Fatalf("rollback must not mutate the original pipeline row, got config_json=%s", currentConfig)
+	}
+
+	var rolledBackCount int
+	if err := repo.db.Get(&rolledBackCount, `SELECT COUNT(*) FROM pipelines p
+		JOIN agent_config_elements e ON e.element_id = p.id
+		WHERE e.version_id = $1 AND p.config_json = $2`, newVersion.Id, `{"op":"v1"}`); err != nil {
+		t.Fatalf("failed to count rolled-back pipelines: %v", err)
+	}
+	if rolledBackCount != 1 {
+		t.Fatalf("expected the new version to link exactly one copy of the v1 config, got %d", rolledBackCount)
+	}
+}
+
+func seedPipeline(t *testing.T, ctx context.Context, repo *Repo, id, configJson string) {
+	t.Helper()
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO pipelines
+		(id, order_id, enabled, name, alias, filter, config_json, deployment_status, deployment_sequence)
+		VALUES ($1, 1, true, $1, '', '', $2, 'DEPLOYED', 1)`, id, configJson)
+	if err != nil {
+		t.Fatalf("failed to seed pipeline %s: %v", id, err)
+	}
+}
+
+func editPipelineConfig(t *testing.T, ctx context.Context, repo *Repo, id, configJson string) {
+	t.Helper()
+	if _, err := repo.db.ExecContext(ctx, `UPDATE pipelines SET config_json = $1 WHERE id = $2`, configJson, id); err != nil {
+		t.Fatalf("failed to edit pipeline %s: %v", id, err)
+	}
+}
+
+func seedVersion(t *testing.T, ctx context.Context, repo *Repo, id string, version int) {
+	t.Helper()
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO agent_config_versions
+		(id, version, element_type, created_by, created_at, reason)
+		VALUES ($1, $2, $3, 'operator', CURRENT_TIMESTAMP, '')`, id, version, logPipelines)
+	if err != nil {
+		t.Fatalf("failed to seed version %s: %v", id, err)
+	}
+}
+
+func linkPipelineToVersion(t *testing.T, ctx context.Context, repo *Repo, pipelineId, versionId string) {
+	t.Helper()
+	_, err := repo.db.ExecContext(ctx, `INSERT INTO agent_config_elements
+		(id, version_id, element_id, element_type)
+		VALUES ($1, $2, $3, $4)`, versionId+"-"+pipelineId, versionId, pipelineId, logPipelines)
+	if err != nil {
+		t.Fatalf("failed to link pipeline %s to version %s: %v", pipelineId, versionId, err)
+	}
+}